@@ -0,0 +1,38 @@
+// Package backend defines the transport abstraction gonnel drives to expose
+// a local address to the internet. ngrok's own HTTP API is the default
+// transport, but anything implementing Backend can be swapped in through
+// gonnel.Options.Backend, e.g. the chisel and go-http-tunnel implementations
+// that live alongside this package.
+package backend
+
+import "context"
+
+// TunnelRequest carries the fields a Backend needs to open or close a
+// tunnel. It mirrors the matching fields of gonnel.Tunnel, with Proto
+// carried as its string form.
+type TunnelRequest struct {
+	Name         string // A name that used for creating or closing
+	Proto        string // Protocol string, e.g. "http", "tcp", "tls"
+	LocalAddress string // Can be host with port or port only
+	Auth         string // Username & password that will authenticate to access tunnel
+	Inspect      bool   // Inspect transaction data tunnel
+}
+
+// TunnelResult is what a Backend hands back once a tunnel is live.
+type TunnelResult struct {
+	RemoteAddress string // Public address assigned to the tunnel
+}
+
+// Backend abstracts the transport used to expose a local address to the
+// internet. Implementations are not required to be safe for concurrent use
+// unless documented otherwise.
+type Backend interface {
+	// Start brings up the backend's control connection to its server.
+	Start(ctx context.Context) error
+	// CreateTunnel opens a new tunnel and returns its remote address.
+	CreateTunnel(ctx context.Context, t *TunnelRequest) (*TunnelResult, error)
+	// CloseTunnel tears down a previously created tunnel.
+	CloseTunnel(ctx context.Context, t *TunnelRequest) error
+	// Close shuts down the backend and releases its resources.
+	Close() error
+}
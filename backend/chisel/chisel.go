@@ -0,0 +1,147 @@
+// Package chisel implements backend.Backend on top of the chisel protocol,
+// letting gonnel open SSH-over-HTTPS multiplexed tunnels against a
+// user-run chisel server instead of ngrok.com.
+package chisel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	chclient "jackfan.us.kg/jpillora/chisel/client"
+
+	"gonnel/backend"
+)
+
+// Config configures the chisel client connection.
+type Config struct {
+	Server    string // chisel server address, e.g. "https://chisel.example.com"
+	Auth      string // "user:pass" if the server requires authentication
+	KeepAlive string // keep-alive interval, e.g. "25s", empty for the client default
+}
+
+// Backend drives a chisel client and maps gonnel tunnels onto chisel
+// remotes. One Backend holds one chisel client connection; CreateTunnel
+// adds a remote and restarts the client, since chisel negotiates all of its
+// remotes at connect time.
+type Backend struct {
+	cfg Config
+
+	mu      sync.Mutex
+	client  *chclient.Client
+	remotes map[string]string // tunnel name -> remote spec
+}
+
+// New returns a chisel Backend for the given server.
+func New(cfg Config) *Backend {
+	return &Backend{
+		cfg:     cfg,
+		remotes: make(map[string]string),
+	}
+}
+
+// Start connects to the configured chisel server with no remotes yet; the
+// connection is re-established as tunnels are added via CreateTunnel.
+func (b *Backend) Start(ctx context.Context) error {
+	if b.cfg.Server == "" {
+		return errors.New("chisel: server address required")
+	}
+	return b.reconnect(ctx)
+}
+
+// CreateTunnel adds a reverse remote for t and reconnects the chisel client
+// so the new remote is negotiated with the server. The remote is requested
+// on the same port t listens on locally, and that port is reported back in
+// RemoteAddress alongside the chisel server's host.
+func (b *Backend) CreateTunnel(ctx context.Context, t *backend.TunnelRequest) (*backend.TunnelResult, error) {
+	host, port, err := splitLocalAddress(t.LocalAddress)
+	if err != nil {
+		return nil, fmt.Errorf("chisel: %w", err)
+	}
+
+	b.mu.Lock()
+	b.remotes[t.Name] = fmt.Sprintf("R:%s:%s:%s", port, host, port)
+	b.mu.Unlock()
+
+	if err := b.reconnect(ctx); err != nil {
+		return nil, err
+	}
+
+	return &backend.TunnelResult{RemoteAddress: fmt.Sprintf("%s:%s", serverHost(b.cfg.Server), port)}, nil
+}
+
+// splitLocalAddress parses a TunnelRequest.LocalAddress ("host:port" or bare
+// "port") into the host chisel should forward to and the port it should
+// request on the server, defaulting the host to localhost.
+func splitLocalAddress(addr string) (host, port string, err error) {
+	if !strings.Contains(addr, ":") {
+		return "localhost", addr, nil
+	}
+	return net.SplitHostPort(addr)
+}
+
+// serverHost strips the scheme, if any, from a chisel server address so it
+// can be combined with a port for display.
+func serverHost(server string) string {
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+len("://"):]
+	}
+	return strings.TrimSuffix(server, "/")
+}
+
+// CloseTunnel removes t's remote and reconnects without it.
+func (b *Backend) CloseTunnel(ctx context.Context, t *backend.TunnelRequest) error {
+	b.mu.Lock()
+	delete(b.remotes, t.Name)
+	b.mu.Unlock()
+
+	return b.reconnect(ctx)
+}
+
+// Close shuts down the chisel client connection.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client == nil {
+		return nil
+	}
+	return b.client.Close()
+}
+
+// reconnect tears down the current chisel client, if any, and starts a new
+// one configured with the current set of remotes.
+func (b *Backend) reconnect(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client != nil {
+		_ = b.client.Close()
+		b.client = nil
+	}
+
+	remotes := make([]string, 0, len(b.remotes))
+	for _, r := range b.remotes {
+		remotes = append(remotes, r)
+	}
+
+	cli, err := chclient.NewClient(&chclient.Config{
+		Server:    b.cfg.Server,
+		Auth:      b.cfg.Auth,
+		KeepAlive: b.cfg.KeepAlive,
+		Remotes:   remotes,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := cli.Start(ctx); err != nil {
+		return err
+	}
+
+	b.client = cli
+	return nil
+}
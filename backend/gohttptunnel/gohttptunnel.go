@@ -0,0 +1,137 @@
+// Package gohttptunnel implements backend.Backend on top of
+// jackfan.us.kg/mmatczuk/go-http-tunnel, an HTTP/2-based control channel that
+// lets gonnel run against a self-hosted tunnel server instead of ngrok.com.
+package gohttptunnel
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	tunnelclient "jackfan.us.kg/mmatczuk/go-http-tunnel"
+	"jackfan.us.kg/mmatczuk/go-http-tunnel/proto"
+
+	"gonnel/backend"
+)
+
+// Config configures the go-http-tunnel client.
+type Config struct {
+	ServerAddr string // tunnel server control address, host:port
+	TLSCrt     string // path to client TLS certificate
+	TLSKey     string // path to client TLS key
+	RootCA     string // path to the CA that signed the server certificate
+}
+
+// Backend drives a go-http-tunnel client. Like chisel and unlike ngrok, the
+// go-http-tunnel client is configured with its full tunnel map up front, so
+// CreateTunnel/CloseTunnel rebuild and restart the client's tunnel set.
+type Backend struct {
+	cfg Config
+
+	mu      sync.Mutex
+	client  *tunnelclient.Client
+	tunnels map[string]*proto.Tunnel
+	addrs   map[string]string // tunnel name -> local address; restart reads the address from tunnels' Host instead
+}
+
+// New returns a go-http-tunnel Backend for the given server.
+func New(cfg Config) *Backend {
+	return &Backend{
+		cfg:     cfg,
+		tunnels: make(map[string]*proto.Tunnel),
+		addrs:   make(map[string]string),
+	}
+}
+
+// Start is a no-op beyond validating configuration; the client itself is
+// started once the first tunnel is created, since go-http-tunnel requires a
+// non-empty tunnel map at construction time.
+func (b *Backend) Start(ctx context.Context) error {
+	if b.cfg.ServerAddr == "" {
+		return errors.New("gohttptunnel: server address required")
+	}
+	return nil
+}
+
+// CreateTunnel adds a proto.Tunnel entry for t and (re)starts the client.
+func (b *Backend) CreateTunnel(ctx context.Context, t *backend.TunnelRequest) (*backend.TunnelResult, error) {
+	user, pass := splitAuth(t.Auth)
+
+	b.mu.Lock()
+	b.tunnels[t.Name] = &proto.Tunnel{
+		Protocol: t.Proto,
+		Host:     t.LocalAddress,
+		Auth:     &proto.Auth{User: user, Password: pass},
+	}
+	b.addrs[t.Name] = t.LocalAddress
+	b.mu.Unlock()
+
+	if err := b.restart(); err != nil {
+		return nil, err
+	}
+
+	return &backend.TunnelResult{RemoteAddress: b.cfg.ServerAddr}, nil
+}
+
+// splitAuth splits a TunnelRequest.Auth "user:pass" string into its two
+// halves. An empty auth, or one with no colon, yields it whole as the user
+// with an empty password.
+func splitAuth(auth string) (user, pass string) {
+	user, pass, _ = strings.Cut(auth, ":")
+	return user, pass
+}
+
+// CloseTunnel removes t's entry and restarts the client without it.
+func (b *Backend) CloseTunnel(ctx context.Context, t *backend.TunnelRequest) error {
+	b.mu.Lock()
+	delete(b.tunnels, t.Name)
+	delete(b.addrs, t.Name)
+	b.mu.Unlock()
+
+	return b.restart()
+}
+
+// Close stops the go-http-tunnel client.
+func (b *Backend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client == nil {
+		return nil
+	}
+	b.client.Stop()
+	b.client = nil
+	return nil
+}
+
+// restart tears down the current client, if any, and starts a new one with
+// the current tunnel map. With no tunnels left, the client stays stopped.
+func (b *Backend) restart() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client != nil {
+		b.client.Stop()
+		b.client = nil
+	}
+
+	if len(b.tunnels) == 0 {
+		return nil
+	}
+
+	cli, err := tunnelclient.NewClient(&tunnelclient.ClientConfig{
+		ServerAddr: b.cfg.ServerAddr,
+		TLSCrt:     b.cfg.TLSCrt,
+		TLSKey:     b.cfg.TLSKey,
+		RootCA:     b.cfg.RootCA,
+		Tunnels:    b.tunnels,
+	})
+	if err != nil {
+		return err
+	}
+
+	go cli.Start()
+	b.client = cli
+	return nil
+}
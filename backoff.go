@@ -0,0 +1,94 @@
+package gonnel
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Stop is returned by Backoff.NextBackOff to indicate that no more retries
+// should be made.
+const Stop time.Duration = -1
+
+// Backoff computes the next delay between retries of an operation that may
+// fail transiently, such as CreateTunnel/CloseTunnel while the binary is
+// still coming up.
+type Backoff interface {
+	// NextBackOff returns the duration to wait before the next retry, or
+	// Stop if the retry budget has been exhausted.
+	NextBackOff() time.Duration
+	// Reset discards any accumulated state so the next NextBackOff call
+	// starts a fresh retry sequence.
+	Reset()
+}
+
+// ExponentialBackoff is the default Backoff implementation: it starts at
+// InitialInterval and multiplies the interval by Multiplier after each
+// attempt, capping at MaxInterval and randomizing by RandomizationFactor so
+// concurrent clients don't retry in lockstep.
+type ExponentialBackoff struct {
+	InitialInterval     time.Duration // delay before the first retry
+	RandomizationFactor float64       // jitter applied to each interval, 0-1
+	Multiplier          float64       // growth factor applied after each attempt
+	MaxInterval         time.Duration // upper bound on the computed interval
+	MaxElapsedTime      time.Duration // give up entirely after this long, 0 means never
+
+	currentInterval time.Duration
+	startTime       time.Time
+}
+
+// DefaultMaxElapsedTime bounds NewExponentialBackoff's retries so a
+// permanently failing operation (bad auth, name conflict, malformed
+// address) eventually returns its error instead of retrying forever.
+const DefaultMaxElapsedTime = 5 * time.Minute
+
+// NewExponentialBackoff returns an ExponentialBackoff configured with
+// sensible defaults: 1s initial interval, x1.5 growth, 30s cap, 0.5 jitter
+// and a 5 minute overall time limit.
+func NewExponentialBackoff() *ExponentialBackoff {
+	b := &ExponentialBackoff{
+		InitialInterval:     1 * time.Second,
+		RandomizationFactor: 0.5,
+		Multiplier:          1.5,
+		MaxInterval:         30 * time.Second,
+		MaxElapsedTime:      DefaultMaxElapsedTime,
+	}
+	b.Reset()
+	return b
+}
+
+// Reset restarts the backoff sequence at InitialInterval.
+func (b *ExponentialBackoff) Reset() {
+	b.currentInterval = b.InitialInterval
+	b.startTime = time.Now()
+}
+
+// NextBackOff returns the next jittered interval, or Stop once
+// MaxElapsedTime has passed.
+func (b *ExponentialBackoff) NextBackOff() time.Duration {
+	if b.currentInterval == 0 {
+		b.Reset()
+	}
+
+	if b.MaxElapsedTime != 0 && time.Since(b.startTime) > b.MaxElapsedTime {
+		return Stop
+	}
+
+	next := b.randomizedInterval()
+	b.currentInterval = time.Duration(float64(b.currentInterval) * b.Multiplier)
+	if b.MaxInterval != 0 && b.currentInterval > b.MaxInterval {
+		b.currentInterval = b.MaxInterval
+	}
+	return next
+}
+
+// randomizedInterval applies RandomizationFactor jitter to currentInterval.
+func (b *ExponentialBackoff) randomizedInterval() time.Duration {
+	if b.RandomizationFactor == 0 {
+		return b.currentInterval
+	}
+
+	delta := b.RandomizationFactor * float64(b.currentInterval)
+	min := float64(b.currentInterval) - delta
+	max := float64(b.currentInterval) + delta
+	return time.Duration(min + (rand.Float64() * (max - min + 1)))
+}
@@ -0,0 +1,110 @@
+package gonnel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffGrowth(t *testing.T) {
+	b := &ExponentialBackoff{
+		InitialInterval: 1 * time.Second,
+		Multiplier:      2,
+		MaxInterval:     10 * time.Second,
+	}
+	b.Reset()
+
+	wait := b.NextBackOff()
+	if wait != 1*time.Second {
+		t.Errorf("first interval = %v, want %v", wait, 1*time.Second)
+	}
+
+	wait = b.NextBackOff()
+	if wait != 2*time.Second {
+		t.Errorf("second interval = %v, want %v", wait, 2*time.Second)
+	}
+
+	wait = b.NextBackOff()
+	if wait != 4*time.Second {
+		t.Errorf("third interval = %v, want %v", wait, 4*time.Second)
+	}
+}
+
+func TestExponentialBackoffCapsAtMaxInterval(t *testing.T) {
+	b := &ExponentialBackoff{
+		InitialInterval: 1 * time.Second,
+		Multiplier:      10,
+		MaxInterval:     5 * time.Second,
+	}
+	b.Reset()
+
+	for i := 0; i < 5; i++ {
+		b.NextBackOff()
+	}
+
+	if b.currentInterval != b.MaxInterval {
+		t.Errorf("currentInterval = %v, want capped at %v", b.currentInterval, b.MaxInterval)
+	}
+}
+
+func TestExponentialBackoffJitterWithinBounds(t *testing.T) {
+	b := &ExponentialBackoff{
+		InitialInterval:     10 * time.Second,
+		RandomizationFactor: 0.5,
+		Multiplier:          1,
+		MaxInterval:         10 * time.Second,
+	}
+	b.Reset()
+
+	min := 5 * time.Second
+	max := 15 * time.Second
+	for i := 0; i < 100; i++ {
+		interval := b.randomizedInterval()
+		if interval < min || interval > max {
+			t.Fatalf("randomizedInterval() = %v, want within [%v, %v]", interval, min, max)
+		}
+	}
+}
+
+func TestExponentialBackoffNoJitter(t *testing.T) {
+	b := &ExponentialBackoff{InitialInterval: 5 * time.Second}
+	b.Reset()
+
+	if got := b.randomizedInterval(); got != 5*time.Second {
+		t.Errorf("randomizedInterval() = %v, want %v", got, 5*time.Second)
+	}
+}
+
+func TestExponentialBackoffStopsAfterMaxElapsedTime(t *testing.T) {
+	b := &ExponentialBackoff{
+		InitialInterval: 1 * time.Millisecond,
+		Multiplier:      1,
+		MaxElapsedTime:  10 * time.Millisecond,
+	}
+	b.Reset()
+	b.startTime = time.Now().Add(-20 * time.Millisecond)
+
+	if wait := b.NextBackOff(); wait != Stop {
+		t.Errorf("NextBackOff() = %v, want Stop once MaxElapsedTime has passed", wait)
+	}
+}
+
+func TestExponentialBackoffNeverStopsWithZeroMaxElapsedTime(t *testing.T) {
+	b := &ExponentialBackoff{InitialInterval: 1 * time.Millisecond, Multiplier: 1}
+	b.Reset()
+	b.startTime = time.Now().Add(-24 * time.Hour)
+
+	if wait := b.NextBackOff(); wait == Stop {
+		t.Error("NextBackOff() returned Stop with MaxElapsedTime unset")
+	}
+}
+
+func TestNewExponentialBackoffDefaults(t *testing.T) {
+	b := NewExponentialBackoff()
+
+	if b.MaxElapsedTime != DefaultMaxElapsedTime {
+		t.Errorf("MaxElapsedTime = %v, want %v", b.MaxElapsedTime, DefaultMaxElapsedTime)
+	}
+	if b.currentInterval != b.InitialInterval {
+		t.Errorf("currentInterval = %v, want %v", b.currentInterval, b.InitialInterval)
+	}
+}
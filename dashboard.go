@@ -0,0 +1,235 @@
+package gonnel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"jackfan.us.kg/gorilla/websocket"
+
+	"gonnel/util"
+)
+
+// dashboardEvent is the envelope published to ServeDashboard's websocket
+// clients. Kind is "tunnel", "update" or "transaction" depending on which
+// of Client.Events, Client.UpdateEvents or Client.StreamTransactions
+// produced Data.
+type dashboardEvent struct {
+	Kind string      `json:"kind"`
+	Data interface{} `json:"data"`
+}
+
+var dashboardUpgrader = websocket.Upgrader{}
+
+// dashboardHTML is the embedded status UI served at "/". It lists the
+// tunnels returned by /api/tunnels and appends events streamed over /_ws.
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>gonnel dashboard</title>
+	<style>
+		body { font-family: sans-serif; margin: 2rem; }
+		table { border-collapse: collapse; }
+		td, th { border: 1px solid #ccc; padding: 0.25rem 0.5rem; text-align: left; }
+		#log { white-space: pre-wrap; font-family: monospace; font-size: 0.85rem; }
+	</style>
+</head>
+<body>
+	<h1>gonnel</h1>
+	<table id="tunnels">
+		<thead><tr><th>Name</th><th>Proto</th><th>Local</th><th>Remote</th><th>Healthy</th></tr></thead>
+		<tbody></tbody>
+	</table>
+	<h2>Events</h2>
+	<div id="log"></div>
+	<script>
+		function renderTunnels(tunnels) {
+			var body = document.querySelector("#tunnels tbody");
+			body.innerHTML = "";
+			(tunnels || []).forEach(function (t) {
+				var row = document.createElement("tr");
+				row.innerHTML = "<td>" + t.Name + "</td><td>" + t.Proto + "</td><td>" +
+					t.LocalAddress + "</td><td>" + t.RemoteAddress + "</td><td>" + t.Healthy + "</td>";
+				body.appendChild(row);
+			});
+		}
+
+		fetch("/api/tunnels").then(function (r) { return r.json(); }).then(renderTunnels);
+
+		var log = document.querySelector("#log");
+		var ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/_ws");
+		ws.onmessage = function (ev) {
+			log.textContent += ev.data + "\n";
+			if (log.textContent.length > 20000) {
+				log.textContent = log.textContent.slice(-20000);
+			}
+			fetch("/api/tunnels").then(function (r) { return r.json(); }).then(renderTunnels);
+		};
+	</script>
+</body>
+</html>
+`
+
+// ServeDashboard exposes an HTTP server with a small embedded status UI, a
+// "/_ws" WebSocket endpoint broadcasting tunnel, update and (when a
+// transaction-inspecting tunnel is present) live HTTP transaction events,
+// and a "/api/tunnels" REST view mirroring Client's tunnels (GET to list,
+// POST to AddTunnel+CreateTunnel a new one).
+//
+// "/_ws" rejects cross-origin upgrades unless the origin is listed in
+// Options.DashboardAllowedOrigins, and requires Options.DashboardToken as a
+// "token" query parameter when one is configured; see checkDashboardOrigin
+// and dashboardAuthorized.
+//
+// ServeDashboard blocks serving addr until it fails; run it in its own
+// goroutine.
+func (c *Client) ServeDashboard(addr string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c.dashboardOnce.Do(func() {
+		c.dashboard = util.NewBroadcast()
+		go c.pumpTunnelEvents()
+		go c.pumpUpdateEvents()
+		go c.pumpTransactions(ctx)
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", c.handleDashboardIndex)
+	mux.HandleFunc("/_ws", c.handleDashboardWS)
+	mux.HandleFunc("/api/tunnels", c.handleAPITunnels)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+// pumpTunnelEvents forwards Client.Events() onto the dashboard broadcast.
+func (c *Client) pumpTunnelEvents() {
+	for ev := range c.Events() {
+		c.publishDashboard("tunnel", ev)
+	}
+}
+
+// pumpUpdateEvents forwards Client.UpdateEvents() onto the dashboard
+// broadcast.
+func (c *Client) pumpUpdateEvents() {
+	for ev := range c.UpdateEvents() {
+		c.publishDashboard("update", ev)
+	}
+}
+
+// pumpTransactions forwards Client.StreamTransactions() onto the dashboard
+// broadcast until ctx is done.
+func (c *Client) pumpTransactions(ctx context.Context) {
+	for txn := range c.StreamTransactions(ctx) {
+		c.publishDashboard("transaction", txn)
+	}
+}
+
+// publishDashboard marshals kind/data as a dashboardEvent and fans it out
+// to every websocket client.
+func (c *Client) publishDashboard(kind string, data interface{}) {
+	payload, err := json.Marshal(dashboardEvent{Kind: kind, Data: data})
+	if err != nil {
+		c.logger().Warn("dashboard: failed to marshal event", "kind", kind, "err", err)
+		return
+	}
+	c.dashboard.Publish(payload)
+}
+
+func (c *Client) handleDashboardIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(dashboardHTML))
+}
+
+// dashboardAuthorized reports whether r may access the dashboard's
+// websocket endpoint. If Options.DashboardToken is set, the request must
+// carry it as a "token" query parameter; otherwise every request is
+// authorized and only checkDashboardOrigin guards against cross-origin use.
+func (c *Client) dashboardAuthorized(r *http.Request) bool {
+	if c.Options == nil || c.Options.DashboardToken == "" {
+		return true
+	}
+	return r.URL.Query().Get("token") == c.Options.DashboardToken
+}
+
+// checkDashboardOrigin rejects cross-origin websocket upgrades unless the
+// Origin is in Options.DashboardAllowedOrigins. With no allowlist
+// configured, only same-origin requests (or requests with no Origin
+// header, e.g. non-browser clients) are allowed.
+func (c *Client) checkDashboardOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if c.Options != nil {
+		for _, allowed := range c.Options.DashboardAllowedOrigins {
+			if origin == allowed {
+				return true
+			}
+		}
+	}
+	return u.Host == r.Host
+}
+
+func (c *Client) handleDashboardWS(w http.ResponseWriter, r *http.Request) {
+	if !c.dashboardAuthorized(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	upgrader := dashboardUpgrader
+	upgrader.CheckOrigin = c.checkDashboardOrigin
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		c.logger().Warn("dashboard: websocket upgrade failed", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := c.dashboard.Subscribe()
+	defer c.dashboard.Unsubscribe(sub)
+
+	for msg := range sub {
+		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			return
+		}
+	}
+}
+
+// handleAPITunnels serves GET for the current tunnel list and POST to
+// AddTunnel+CreateTunnel a new one from a JSON-encoded Tunnel body.
+func (c *Client) handleAPITunnels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Tunnel)
+
+	case http.MethodPost:
+		var t Tunnel
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		c.AddTunnel(&t)
+		if err := c.CreateTunnel(&t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&t)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,91 @@
+package gonnel
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckDashboardOriginSameOrigin(t *testing.T) {
+	c := &Client{}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+	r.Header.Set("Origin", "http://dash.local")
+
+	if !c.checkDashboardOrigin(r) {
+		t.Error("same-origin request rejected")
+	}
+}
+
+func TestCheckDashboardOriginNoOriginHeader(t *testing.T) {
+	c := &Client{}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+
+	if !c.checkDashboardOrigin(r) {
+		t.Error("request with no Origin header rejected")
+	}
+}
+
+func TestCheckDashboardOriginForeignRejected(t *testing.T) {
+	c := &Client{}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+	r.Header.Set("Origin", "http://evil.example")
+
+	if c.checkDashboardOrigin(r) {
+		t.Error("foreign origin accepted")
+	}
+}
+
+func TestCheckDashboardOriginAllowlisted(t *testing.T) {
+	c := &Client{Options: &Options{DashboardAllowedOrigins: []string{"http://allowed.example"}}}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+	r.Header.Set("Origin", "http://allowed.example")
+
+	if !c.checkDashboardOrigin(r) {
+		t.Error("allowlisted origin rejected")
+	}
+}
+
+func TestCheckDashboardOriginNotAllowlisted(t *testing.T) {
+	c := &Client{Options: &Options{DashboardAllowedOrigins: []string{"http://allowed.example"}}}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+	r.Header.Set("Origin", "http://evil.example")
+
+	if c.checkDashboardOrigin(r) {
+		t.Error("non-allowlisted origin accepted despite an allowlist being configured")
+	}
+}
+
+func TestDashboardAuthorizedNoToken(t *testing.T) {
+	c := &Client{}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+
+	if !c.dashboardAuthorized(r) {
+		t.Error("request rejected with no token configured")
+	}
+}
+
+func TestDashboardAuthorizedMatchingToken(t *testing.T) {
+	c := &Client{Options: &Options{DashboardToken: "secret"}}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws?token=secret", nil)
+
+	if !c.dashboardAuthorized(r) {
+		t.Error("request with matching token rejected")
+	}
+}
+
+func TestDashboardAuthorizedMissingToken(t *testing.T) {
+	c := &Client{Options: &Options{DashboardToken: "secret"}}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws", nil)
+
+	if c.dashboardAuthorized(r) {
+		t.Error("request with no token accepted despite a token being configured")
+	}
+}
+
+func TestDashboardAuthorizedWrongToken(t *testing.T) {
+	c := &Client{Options: &Options{DashboardToken: "secret"}}
+	r := httptest.NewRequest("GET", "http://dash.local/_ws?token=wrong", nil)
+
+	if c.dashboardAuthorized(r) {
+		t.Error("request with wrong token accepted")
+	}
+}
@@ -20,6 +20,10 @@
 //	go client.StartServer(done)
 //	<-done
 //
+// gonnel talks to ngrok's binary by default, but Options.Backend lets you
+// swap in a self-hosted alternative (see the gonnel/backend sub-packages for
+// chisel and go-http-tunnel implementations) without changing the Tunnel API.
+//
 // This package also can directly create tunnel if you
 // already started ngrok binary separately,
 // WebUIAddress type need hostname and port
@@ -41,19 +45,25 @@
 //	if err := client.CreateTunnel(t); err != nil {
 //		log.Fatalln(err)
 //	}
-//
 package gonnel
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
-	"log"
+	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"regexp"
 	"sync"
 	"syscall"
+	"time"
+
+	"gonnel/backend"
+	gonnellog "gonnel/log"
+	"gonnel/util"
 )
 
 // Protocol type
@@ -74,30 +84,69 @@ var protocols = [...]string{
 
 func (p Protocol) String() string { return protocols[p] }
 
+// MarshalJSON encodes Protocol as its string name, e.g. "http", so the
+// dashboard's /api/tunnels view reads naturally.
+func (p Protocol) MarshalJSON() ([]byte, error) { return json.Marshal(p.String()) }
+
+// UnmarshalJSON decodes a Protocol from its string name.
+func (p *Protocol) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	for i, name := range protocols {
+		if name == s {
+			*p = Protocol(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("gonnel: unknown protocol %q", s)
+}
+
 // Options that represents command that will be used to start binary
 //
 // Not all of this option necessary, if AuthToken provided then
 // binary will run auth token first.
 type Options struct {
-	SubDomain     string // Sub domain config if you're using premium plan
-	AuthToken     string // Auth token to authenticate client
-	Region        string // Region that will tunneling from
-	ConfigPath    string // Path config to store auth token or specific WebUI port
-	BinaryPath    string // Binary file that will be running
-	LogBinary     bool   // You can watch binary log or not
-	IgnoreSignals bool   // Run child processes in a separate process group to ignore signals
+	SubDomain     string           // Sub domain config if you're using premium plan
+	AuthToken     string           // Auth token to authenticate client
+	Region        string           // Region that will tunneling from
+	ConfigPath    string           // Path config to store auth token or specific WebUI port
+	BinaryPath    string           // Binary file that will be running
+	LogBinary     bool             // You can watch binary log or not
+	IgnoreSignals bool             // Run child processes in a separate process group to ignore signals
+	Backend       backend.Backend  // Tunnel backend to use instead of the ngrok binary, e.g. chisel or go-http-tunnel
+	Backoff       func() Backoff   // Retry policy factory for CreateTunnel/CloseTunnel, defaults to NewExponentialBackoff
+	Logger        gonnellog.Logger // Logger for gonnel diagnostics, defaults to a PrefixLogger over the standard logger
+
+	UpdateEndpoint     string     // Endpoint CheckForUpdate polls for newer binary releases
+	UpdatePublicKeyPEM []byte     // PEM-encoded public key used to verify a downloaded binary's signature
+	UpdateMode         UpdateMode // ManualUpdate (default) surfaces updates via UpdateEvents, AutoUpdate applies them immediately
+
+	DashboardAllowedOrigins []string // Origins allowed to open ServeDashboard's websocket cross-origin; defaults to same-origin only
+	DashboardToken          string   // If set, ServeDashboard requires this value as a "token" query parameter
 }
 
 // Client that provides all option and tunnel
 //
 // You don't need NewClient method if server client already started
 type Client struct {
-	Options      *Options  // Options that will be used for command
-	Tunnel       []*Tunnel // List of all tunnel
-	WebUIAddress string    // Client server for API communication
-	LogApi       bool      // Log response from API or not
-	commands     []string  // result of commands that will be used to run binary
-	runningCmd   *exec.Cmd // Pointer of command that running
+	Options           *Options         // Options that will be used for command
+	Tunnel            []*Tunnel        // List of all tunnel
+	WebUIAddress      string           // Client server for API communication
+	LogApi            bool             // Log response from API or not
+	HeartbeatInterval time.Duration    // How often Watch pings tunnels, defaults to DefaultHeartbeatInterval
+	MaxPongLatency    time.Duration    // How long Watch tolerates a missed pong before reconnecting, defaults to DefaultMaxPongLatency
+	commands          []string         // result of commands that will be used to run binary
+	runningCmd        *exec.Cmd        // Pointer of command that running
+	backendOnce       sync.Once        // ensures Options.Backend is started exactly once
+	backendErr        error            // result of the one-time Options.Backend.Start call
+	eventsOnce        sync.Once        // ensures events is created exactly once
+	events            chan TunnelEvent // see Events
+	updateEventsOnce  sync.Once        // ensures updateEvents is created exactly once
+	updateEvents      chan UpdateEvent // see UpdateEvents
+	dashboardOnce     sync.Once        // ensures the dashboard fan-out is started exactly once
+	dashboard         *util.Broadcast  // fans tunnel/update/transaction events out to ServeDashboard's websocket clients
 }
 
 // Constant regex that will be used for handling stdout command
@@ -108,12 +157,39 @@ const (
 	webURI           = `\d+\.\d+\.\d+\.\d+:\d+`                                // Find client server
 )
 
+// defaultLogger is used whenever Options.Logger is unset.
+func defaultLogger() gonnellog.Logger {
+	return gonnellog.NewPrefixLogger(gonnellog.NewStdLogger(nil), "gonnel")
+}
+
+// logger returns the Logger to use for diagnostics, falling back to
+// defaultLogger when Options or Options.Logger is unset.
+func (c *Client) logger() gonnellog.Logger {
+	if c.Options != nil && c.Options.Logger != nil {
+		return c.Options.Logger
+	}
+	return defaultLogger()
+}
+
+// tunnelLogger returns a Logger tagged with the tunnel's name, e.g.
+// "[gonnel][tunnel:awesome]".
+func (c *Client) tunnelLogger(name string) gonnellog.Logger {
+	if pl, ok := c.logger().(*gonnellog.PrefixLogger); ok {
+		return pl.With("tunnel:" + name)
+	}
+	return gonnellog.NewPrefixLogger(c.logger(), "tunnel:"+name)
+}
+
 // NewClient that return Client pointer
 //
 // Client pointer can be used to close binary or start binary
 func NewClient(opt Options) (*Client, error) {
-	log.Println("New client")
-	if opt.BinaryPath == "" {
+	logger := opt.Logger
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	logger.Info("New client")
+	if opt.BinaryPath == "" && opt.Backend == nil {
 		return nil, errors.New("binary path required")
 	}
 
@@ -168,7 +244,11 @@ func (o *Options) AuthTokenCommand() error {
 		return errors.New(errBuffer.String())
 	}
 
-	log.Println(outBuffer.String())
+	logger := o.Logger
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	logger.Info(outBuffer.String())
 	return nil
 }
 
@@ -176,11 +256,29 @@ func (o *Options) AuthTokenCommand() error {
 //
 // Channel needed to send information about WebUI started or not.
 // stdout will be pipe and check using regex.
+//
+// StartServer delegates to StartServerContext with context.Background and
+// logs any error the read loop produces instead of returning it; use
+// StartServerContext directly if you need to observe or cancel it.
 func (c *Client) StartServer(isReady chan bool) {
-	log.Println("Start server")
+	errs := make(chan error, 1)
+	go func() {
+		if err, ok := <-errs; ok {
+			c.logger().Error("server stopped", "err", err)
+		}
+	}()
+	c.StartServerContext(context.Background(), isReady, errs)
+}
+
+// StartServerContext is StartServer with a context that stops the binary
+// and its stdout read loop, and an errs channel that receives the read
+// loop's terminal error (a closed stdout, "address already in use", or a
+// session limit message) instead of calling log.Fatalln.
+func (c *Client) StartServerContext(ctx context.Context, isReady chan bool, errs chan<- error) {
+	c.logger().Info("Start server")
 
 	commands := c.Options.generateCommands()
-	cmd := exec.Command(c.Options.BinaryPath, commands...)
+	cmd := exec.CommandContext(ctx, c.Options.BinaryPath, commands...)
 	if c.Options.IgnoreSignals {
 		cmd.SysProcAttr = &syscall.SysProcAttr{
 			Setpgid: true,
@@ -190,11 +288,13 @@ func (c *Client) StartServer(isReady chan bool) {
 	c.runningCmd = cmd
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		log.Fatal(err)
+		errs <- err
+		return
 	}
 
 	if err := cmd.Start(); err != nil {
-		log.Fatal(err)
+		errs <- err
+		return
 	}
 
 	if !c.Options.IgnoreSignals {
@@ -208,30 +308,41 @@ func (c *Client) StartServer(isReady chan bool) {
 
 	checkNGReady, err := regexp.Compile(ngReady)
 	if err != nil {
-		log.Fatalln(err)
+		errs <- err
+		return
 	}
 
 	checkNGInUse, err := regexp.Compile(ngInUse)
 	if err != nil {
-		log.Fatalln(err)
+		errs <- err
+		return
 	}
 
 	checkSessionLimit, err := regexp.Compile(ngSessionLimited)
 	if err != nil {
-		log.Fatalln(err)
+		errs <- err
+		return
 	}
 
 	checkWebURI, err := regexp.Compile(webURI)
 	if err != nil {
-		log.Fatalln(err)
+		errs <- err
+		return
 	}
 
 	chunk := make([]byte, 256)
 	for {
+		select {
+		case <-ctx.Done():
+			errs <- ctx.Err()
+			return
+		default:
+		}
+
 		n, err := stdout.Read(chunk)
 		if err != nil {
-			log.Fatalln(err)
-			os.Exit(1)
+			errs <- err
+			return
 		}
 
 		if n < 1 {
@@ -239,22 +350,24 @@ func (c *Client) StartServer(isReady chan bool) {
 		}
 
 		if c.Options.LogBinary {
-			log.Print("Client-Bin-Log: ", string(chunk[:n]))
+			c.logger().Debug("Client-Bin-Log", "output", string(chunk[:n]))
 		}
 		// handle regex (output) that search local ip and port for web ui
 		if checkNGReady.Match(chunk[:n]) {
 			host := checkWebURI.FindStringSubmatch(string(chunk[:n]))
 			if len(host) >= 1 {
-				log.Println("server client ready")
+				c.logger().Info("server client ready")
 				c.WebUIAddress = host[0]
 				isReady <- true
 			}
 		}
 		if checkNGInUse.Match(chunk[:n]) {
-			log.Fatalln("Address already in use")
+			errs <- errors.New("address already in use")
+			return
 		}
 		if checkSessionLimit.Match(chunk[:n]) {
-			log.Fatalln("Limit session reached for this account")
+			errs <- errors.New("limit session reached for this account")
+			return
 		}
 	}
 }
@@ -284,7 +397,7 @@ func (c *Client) handleSignalInput(signalChan chan os.Signal) {
 		s := <-signalChan
 		switch s {
 		default:
-			log.Println(s)
+			c.logger().Info("signal received", "signal", s)
 			c.Signal(s)
 			os.Exit(1)
 		}
@@ -293,7 +406,7 @@ func (c *Client) handleSignalInput(signalChan chan os.Signal) {
 
 // AddTunnel create a new tunnel without connecting it
 func (c *Client) AddTunnel(t *Tunnel) {
-	log.Println("Add tunnel")
+	c.tunnelLogger(t.Name).Info("Add tunnel")
 	c.Tunnel = append(c.Tunnel, t)
 }
 
@@ -301,7 +414,7 @@ func (c *Client) AddTunnel(t *Tunnel) {
 func (c *Client) ConnectAll() error {
 	wg := &sync.WaitGroup{}
 	// api request post to /api/tunnels
-	log.Println("Connecting")
+	c.logger().Info("Connecting")
 
 	if len(c.Tunnel) < 1 {
 		return errors.New("need at least 1 tunnel to connect")
@@ -325,7 +438,7 @@ func (c *Client) ConnectAll() error {
 func (c *Client) DisconnectAll() error {
 	wg := &sync.WaitGroup{}
 	//	api request delete to /api/tunnels/:Name
-	log.Println("Disconnecting")
+	c.logger().Info("Disconnecting")
 	if len(c.Tunnel) < 1 {
 		return errors.New("need at least 1 tunnel to disconnect")
 	}
@@ -0,0 +1,91 @@
+// Package log defines the small leveled logging interface gonnel threads
+// through Client, Tunnel and StartServer, so library users can route its
+// diagnostics into their own observability stack instead of the
+// package-level standard logger gonnel used previously.
+package log
+
+import (
+	"fmt"
+	stdlog "log"
+	"log/slog"
+)
+
+// Logger is a minimal leveled logging interface. kv is an optional list of
+// alternating key/value pairs, following the same convention as slog.
+type Logger interface {
+	Debug(msg string, kv ...any)
+	Info(msg string, kv ...any)
+	Warn(msg string, kv ...any)
+	Error(msg string, kv ...any)
+}
+
+// PrefixLogger wraps another Logger and prepends a component tag, e.g.
+// "[gonnel][tunnel:awesome]", to every message it forwards.
+type PrefixLogger struct {
+	next   Logger
+	prefix string
+}
+
+// NewPrefixLogger returns a PrefixLogger that tags every message logged
+// through next with "[tag]".
+func NewPrefixLogger(next Logger, tag string) *PrefixLogger {
+	return &PrefixLogger{next: next, prefix: "[" + tag + "]"}
+}
+
+// With returns a new PrefixLogger that appends another tag, letting
+// callers build up "[gonnel][tunnel:awesome]" style prefixes incrementally
+// without re-wrapping the underlying Logger.
+func (l *PrefixLogger) With(tag string) *PrefixLogger {
+	return &PrefixLogger{next: l.next, prefix: l.prefix + "[" + tag + "]"}
+}
+
+func (l *PrefixLogger) Debug(msg string, kv ...any) { l.next.Debug(l.prefix+" "+msg, kv...) }
+func (l *PrefixLogger) Info(msg string, kv ...any)  { l.next.Info(l.prefix+" "+msg, kv...) }
+func (l *PrefixLogger) Warn(msg string, kv ...any)  { l.next.Warn(l.prefix+" "+msg, kv...) }
+func (l *PrefixLogger) Error(msg string, kv ...any) { l.next.Error(l.prefix+" "+msg, kv...) }
+
+// StdLogger adapts the standard library *log.Logger to Logger. All levels
+// go through Println since *log.Logger has no concept of level; the level
+// name is folded into the message instead.
+type StdLogger struct {
+	*stdlog.Logger
+}
+
+// NewStdLogger wraps l, or stdlog.Default() if l is nil.
+func NewStdLogger(l *stdlog.Logger) *StdLogger {
+	if l == nil {
+		l = stdlog.Default()
+	}
+	return &StdLogger{Logger: l}
+}
+
+func (l *StdLogger) Debug(msg string, kv ...any) { l.Logger.Println(format("DEBUG", msg, kv)) }
+func (l *StdLogger) Info(msg string, kv ...any)  { l.Logger.Println(format("INFO", msg, kv)) }
+func (l *StdLogger) Warn(msg string, kv ...any)  { l.Logger.Println(format("WARN", msg, kv)) }
+func (l *StdLogger) Error(msg string, kv ...any) { l.Logger.Println(format("ERROR", msg, kv)) }
+
+func format(level, msg string, kv []any) string {
+	s := level + ": " + msg
+	for i := 0; i+1 < len(kv); i += 2 {
+		s += fmt.Sprintf(" %v=%v", kv[i], kv[i+1])
+	}
+	return s
+}
+
+// SlogLogger adapts *slog.Logger to Logger.
+type SlogLogger struct {
+	*slog.Logger
+}
+
+// NewSlogLogger wraps l, or slog.Default() if l is nil.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{Logger: l}
+}
+
+func (l *SlogLogger) Debug(msg string, kv ...any) { l.Logger.Debug(msg, kv...) }
+func (l *SlogLogger) Info(msg string, kv ...any)  { l.Logger.Info(msg, kv...) }
+func (l *SlogLogger) Warn(msg string, kv ...any)  { l.Logger.Warn(msg, kv...) }
+func (l *SlogLogger) Error(msg string, kv ...any) { l.Logger.Error(msg, kv...) }
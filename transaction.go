@@ -0,0 +1,166 @@
+package gonnel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// HTTPTransaction represents a single HTTP request/response pair captured
+// by the binary's inspector and exposed through /api/requests/http.
+type HTTPTransaction struct {
+	ID         string    `json:"id"`
+	TunnelName string    `json:"tunnel_name"`
+	Request    []byte    `json:"request"`
+	Response   []byte    `json:"response"`
+	Start      time.Time `json:"start"`
+	Duration   int64     `json:"duration"` // duration in nanoseconds
+}
+
+// responseListTransactions mirrors the payload returned by
+// GET /api/requests/http
+type responseListTransactions struct {
+	Txns []struct {
+		ID         string    `json:"id"`
+		TunnelName string    `json:"tunnel_name"`
+		Request    []byte    `json:"request"`
+		Response   []byte    `json:"response"`
+		Start      time.Time `json:"start"`
+		Duration   int64     `json:"duration"`
+	} `json:"txns"`
+}
+
+// ListTransactions return all HTTP transactions captured for tunnelName.
+//
+// Inspect must be enabled on the tunnel for the binary to record anything.
+func (c *Client) ListTransactions(tunnelName string) ([]*HTTPTransaction, error) {
+	url := fmt.Sprintf("http://%s/api/requests/http", c.WebUIAddress)
+	res, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return nil, fmt.Errorf("error api: %s", string(body))
+	}
+
+	var record responseListTransactions
+	if err := json.NewDecoder(res.Body).Decode(&record); err != nil {
+		return nil, err
+	}
+
+	txns := make([]*HTTPTransaction, 0, len(record.Txns))
+	for _, t := range record.Txns {
+		if tunnelName != "" && t.TunnelName != tunnelName {
+			continue
+		}
+		txns = append(txns, &HTTPTransaction{
+			ID:         t.ID,
+			TunnelName: t.TunnelName,
+			Request:    t.Request,
+			Response:   t.Response,
+			Start:      t.Start,
+			Duration:   t.Duration,
+		})
+	}
+	return txns, nil
+}
+
+// GetTransaction return a single HTTP transaction by id.
+func (c *Client) GetTransaction(id string) (*HTTPTransaction, error) {
+	url := fmt.Sprintf("http://%s/api/requests/http/%s", c.WebUIAddress, id)
+	res, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return nil, fmt.Errorf("error api: %s", string(body))
+	}
+
+	var t HTTPTransaction
+	if err := json.NewDecoder(res.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ReplayTransaction asks the binary to resend a previously captured request
+// to its original destination.
+func (c *Client) ReplayTransaction(id string) error {
+	url := fmt.Sprintf("http://%s/api/requests/http/%s/replay", c.WebUIAddress, id)
+	res, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("error api: %s", string(body))
+	}
+	return nil
+}
+
+// TransactionPollInterval is the default delay between polls performed by
+// StreamTransactions.
+const TransactionPollInterval = 2 * time.Second
+
+// TransactionSeenWindow bounds how long StreamTransactions remembers a
+// transaction ID for dedup purposes, keyed off HTTPTransaction.Start. The
+// binary only keeps a limited backlog of transactions itself, so anything
+// this old has long since scrolled out of ListTransactions and can be
+// forgotten - without this, seen would grow for the life of the process.
+const TransactionSeenWindow = 10 * time.Minute
+
+// StreamTransactions polls ListTransactions for every tunnel and pushes
+// newly seen transactions to the returned channel until ctx is done.
+//
+// The channel is closed once polling stops.
+func (c *Client) StreamTransactions(ctx context.Context) <-chan *HTTPTransaction {
+	out := make(chan *HTTPTransaction)
+
+	go func() {
+		defer close(out)
+		seen := make(map[string]time.Time)
+		ticker := time.NewTicker(TransactionPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				txns, err := c.ListTransactions("")
+				if err != nil {
+					continue
+				}
+				for _, t := range txns {
+					if _, ok := seen[t.ID]; ok {
+						continue
+					}
+					seen[t.ID] = t.Start
+					select {
+					case out <- t:
+					case <-ctx.Done():
+						return
+					}
+				}
+				for id, start := range seen {
+					if time.Since(start) > TransactionSeenWindow {
+						delete(seen, id)
+					}
+				}
+			}
+		}
+	}()
+
+	return out
+}
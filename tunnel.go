@@ -2,105 +2,197 @@ package gonnel
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"time"
+
+	"gonnel/backend"
 )
 
 // Tunnel will be used in creating or closing a tunnel.
 // a tunnel also can connect to ngrok directly
 // as long ngrok client server already running
 type Tunnel struct {
-	Proto         Protocol // Protocol that use in tunneling process
-	Name          string   // A name that used for creating or closing
-	LocalAddress  string   // Can be host with port or port only
-	Auth          string   // Username & password that will authenticate to access tunnel
-	Inspect       bool     // Inspect transaction data tunnel that will be logged in binary file
-	RemoteAddress string   // Result ngrok connection address
-	IsCreated     bool     // Information tunnel created or not
+	Proto               Protocol  // Protocol that use in tunneling process
+	Name                string    // A name that used for creating or closing
+	LocalAddress        string    // Can be host with port or port only
+	Auth                string    // Username & password that will authenticate to access tunnel
+	Inspect             bool      // Inspect transaction data tunnel that will be logged in binary file
+	RemoteAddress       string    // Result ngrok connection address
+	IsCreated           bool      // Information tunnel created or not
+	Healthy             bool      // Whether the last Client.Watch heartbeat got a pong in time
+	LastPong            time.Time // When the last successful heartbeat was observed
+	ConsecutiveFailures int       // Number of heartbeats in a row that failed to pong
+
+	reconnectBackoff Backoff // retry policy for Watch's automatic reconnect, reset whenever the tunnel is healthy again
 }
 
-// Maximum retries until tunnel connected/closed
-const maxRetries = 100
+// responseCreateTunnel is the payload returned by a successful
+// POST /api/tunnels call.
+type responseCreateTunnel struct {
+	PublicURL string `json:"public_url"`
+}
+
+// backoff returns the retry policy to use for CreateTunnel/CloseTunnel,
+// falling back to NewExponentialBackoff when Options.Backoff is unset.
+func (c *Client) backoff() Backoff {
+	if c.Options != nil && c.Options.Backoff != nil {
+		return c.Options.Backoff()
+	}
+	return NewExponentialBackoff()
+}
 
 // CreateTunnel that create connection to ngrok server
 //
 // Error will be from api ngrok server client, retries is used because server client
 // not always success when started. Need at least 1 or 2 second to start.
-func (c *Client) CreateTunnel(t *Tunnel) (err error) {
-	for attempt := uint(0); attempt <= maxRetries; attempt++ {
-		err = func() error {
-			log.Printf("Creating tunnel %d attempt \n", attempt)
-			time.Sleep(1 * time.Second)
-			var record responseCreateTunnel
-			jsonData := map[string]interface{}{
-				"addr":    t.LocalAddress,
-				"proto":   t.Proto.String(),
-				"name":    t.Name,
-				"inspect": t.Inspect,
-				"auth":    t.Auth,
-			}
-
-			if t.Proto.String() == "http" {
-				jsonData["bind_tls"] = true
-			}
-
-			url := fmt.Sprintf("http://%s/api/tunnels", c.WebUIAddress)
-			jsonValue, err := json.Marshal(jsonData)
-			if err != nil {
-				return err
-			}
-			res, err := http.Post(url, "application/json", bytes.NewBuffer(jsonValue))
-			if err != nil {
-				return err
-			}
-			defer res.Body.Close()
-
-			if res.StatusCode < 200 || res.StatusCode > 299 {
-				res, _ := ioutil.ReadAll(res.Body)
-				return errors.New("error api: " + string(res))
-			}
+//
+// CreateTunnel delegates to CreateTunnelContext with context.Background.
+func (c *Client) CreateTunnel(t *Tunnel) error {
+	return c.CreateTunnelContext(context.Background(), t)
+}
 
-			if err := json.NewDecoder(res.Body).Decode(&record); err != nil {
-				return err
-			}
+// CreateTunnelContext is CreateTunnel with a context that can cancel the
+// retry loop early.
+//
+// If Options.Backend is set, the tunnel is created through that backend
+// instead of calling ngrok's HTTP API directly.
+func (c *Client) CreateTunnelContext(ctx context.Context, t *Tunnel) error {
+	if c.Options != nil && c.Options.Backend != nil {
+		return c.createTunnelBackend(ctx, t)
+	}
+	return c.createTunnelNgrok(ctx, t)
+}
 
-			t.RemoteAddress = record.PublicURL
-			t.IsCreated = true
-			log.Println("tunnel " + t.Name + " is created using: " + t.RemoteAddress + " address")
+// createTunnelNgrok calls ngrok's HTTP API directly, retrying with backoff
+// until it succeeds, the backoff is exhausted, or ctx is done.
+func (c *Client) createTunnelNgrok(ctx context.Context, t *Tunnel) (err error) {
+	logger := c.tunnelLogger(t.Name)
+	b := c.backoff()
+	for attempt := 0; ; attempt++ {
+		logger.Debug("creating tunnel", "attempt", attempt)
+		err = c.createTunnelNgrokOnce(ctx, t)
+		if err == nil {
 			return nil
-		}()
-		if c.LogApi && err != nil {
-			log.Println(err)
 		}
-		if err == nil {
-			break
+		if c.LogApi {
+			logger.Warn("create tunnel failed", "err", err)
+		}
+
+		wait := b.NextBackOff()
+		if wait == Stop {
+			return err
 		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// createTunnelNgrokOnce makes a single, non-retrying attempt to create t
+// through ngrok's HTTP API. createTunnelNgrok wraps this in its own backoff
+// loop; Watch's heartbeat calls it through createTunnelOnce so it owns the
+// retry pacing itself instead of stacking two independent backoffs.
+func (c *Client) createTunnelNgrokOnce(ctx context.Context, t *Tunnel) error {
+	logger := c.tunnelLogger(t.Name)
+	var record responseCreateTunnel
+	jsonData := map[string]interface{}{
+		"addr":    t.LocalAddress,
+		"proto":   t.Proto.String(),
+		"name":    t.Name,
+		"inspect": t.Inspect,
+		"auth":    t.Auth,
 	}
-	return
+
+	if t.Proto.String() == "http" {
+		jsonData["bind_tls"] = true
+	}
+
+	url := fmt.Sprintf("http://%s/api/tunnels", c.WebUIAddress)
+	jsonValue, err := json.Marshal(jsonData)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res, _ := ioutil.ReadAll(res.Body)
+		return errors.New("error api: " + string(res))
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&record); err != nil {
+		return err
+	}
+
+	t.RemoteAddress = record.PublicURL
+	t.IsCreated = true
+	logger.Info("tunnel created", "address", t.RemoteAddress)
+	return nil
+}
+
+// createTunnelOnce makes a single, non-retrying attempt to (re)create t,
+// through Options.Backend if set or ngrok's HTTP API otherwise. It's used by
+// Watch's heartbeat, which paces its own retries via each Tunnel's
+// reconnectBackoff and shouldn't also wait out CreateTunnelContext's
+// internal backoff on every tick.
+func (c *Client) createTunnelOnce(ctx context.Context, t *Tunnel) error {
+	if c.Options != nil && c.Options.Backend != nil {
+		return c.createTunnelBackend(ctx, t)
+	}
+	return c.createTunnelNgrokOnce(ctx, t)
 }
 
 // CloseTunnel that close tunnel from ngrok server
 //
-// Close tunnel call API using DELETE method
-func (c *Client) CloseTunnel(t *Tunnel) (err error) {
-	for attempt := uint(0); attempt <= maxRetries; attempt++ {
+// Close tunnel call API using DELETE method.
+//
+// CloseTunnel delegates to CloseTunnelContext with context.Background.
+func (c *Client) CloseTunnel(t *Tunnel) error {
+	return c.CloseTunnelContext(context.Background(), t)
+}
+
+// CloseTunnelContext is CloseTunnel with a context that can cancel the
+// retry loop early.
+//
+// If Options.Backend is set, the tunnel is closed through that backend
+// instead.
+func (c *Client) CloseTunnelContext(ctx context.Context, t *Tunnel) error {
+	if c.Options != nil && c.Options.Backend != nil {
+		return c.closeTunnelBackend(ctx, t)
+	}
+	return c.closeTunnelNgrok(ctx, t)
+}
+
+// closeTunnelNgrok calls ngrok's HTTP API directly, retrying with backoff
+// until it succeeds, the backoff is exhausted, or ctx is done.
+func (c *Client) closeTunnelNgrok(ctx context.Context, t *Tunnel) (err error) {
+	logger := c.tunnelLogger(t.Name)
+	b := c.backoff()
+	for {
 		err = func() error {
-			log.Println("Closing tunnel in " + t.RemoteAddress)
+			logger.Debug("closing tunnel", "address", t.RemoteAddress)
 			url := fmt.Sprintf("http://%s/api/tunnels/%s", c.WebUIAddress, t.Name)
-			req, err := http.NewRequest("DELETE", url, nil)
+			req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 			if err != nil {
-				log.Println(err)
 				return err
 			}
-			client := &http.Client{}
-			res, err := client.Do(req)
+			res, err := http.DefaultClient.Do(req)
 			if err != nil {
-				log.Println(err)
 				return err
 			}
 			defer res.Body.Close()
@@ -112,15 +204,77 @@ func (c *Client) CloseTunnel(t *Tunnel) (err error) {
 
 			t.RemoteAddress = ""
 			t.IsCreated = false
-			log.Println("Tunnel " + t.Name + " successfully closed")
+			logger.Info("tunnel closed")
 			return nil
 		}()
-		if c.LogApi && err != nil {
-			log.Println(err)
-		}
 		if err == nil {
-			break
+			return nil
+		}
+		if c.LogApi {
+			logger.Warn("close tunnel failed", "err", err)
+		}
+
+		wait := b.NextBackOff()
+		if wait == Stop {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
 		}
 	}
-	return
+}
+
+// createTunnelBackend starts Options.Backend if needed and opens t through
+// it instead of ngrok's HTTP API.
+func (c *Client) createTunnelBackend(ctx context.Context, t *Tunnel) error {
+	c.backendOnce.Do(func() {
+		c.backendErr = c.Options.Backend.Start(ctx)
+	})
+	if c.backendErr != nil {
+		return c.backendErr
+	}
+
+	logger := c.tunnelLogger(t.Name)
+	result, err := c.Options.Backend.CreateTunnel(ctx, &backend.TunnelRequest{
+		Name:         t.Name,
+		Proto:        t.Proto.String(),
+		LocalAddress: t.LocalAddress,
+		Auth:         t.Auth,
+		Inspect:      t.Inspect,
+	})
+	if err != nil {
+		if c.LogApi {
+			logger.Warn("create tunnel failed", "err", err)
+		}
+		return err
+	}
+
+	t.RemoteAddress = result.RemoteAddress
+	t.IsCreated = true
+	logger.Info("tunnel created", "address", t.RemoteAddress)
+	return nil
+}
+
+// closeTunnelBackend closes t through Options.Backend.
+func (c *Client) closeTunnelBackend(ctx context.Context, t *Tunnel) error {
+	logger := c.tunnelLogger(t.Name)
+	if err := c.Options.Backend.CloseTunnel(ctx, &backend.TunnelRequest{
+		Name:         t.Name,
+		Proto:        t.Proto.String(),
+		LocalAddress: t.LocalAddress,
+		Auth:         t.Auth,
+		Inspect:      t.Inspect,
+	}); err != nil {
+		if c.LogApi {
+			logger.Warn("close tunnel failed", "err", err)
+		}
+		return err
+	}
+
+	t.RemoteAddress = ""
+	t.IsCreated = false
+	logger.Info("tunnel closed")
+	return nil
 }
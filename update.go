@@ -0,0 +1,143 @@
+package gonnel
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"gonnel/update"
+)
+
+// UpdateResult is a candidate binary update found by CheckForUpdate.
+type UpdateResult = update.Result
+
+// UpdateMode selects who applies an update once CheckForUpdate finds one.
+type UpdateMode = update.Mode
+
+// Update initiative modes.
+const (
+	ManualUpdate UpdateMode = update.Manual // surface the update, let the caller decide
+	AutoUpdate   UpdateMode = update.Auto   // apply the update immediately if permissions allow
+)
+
+// UpdateEventType identifies the kind of UpdateEvent emitted on
+// Client.UpdateEvents().
+type UpdateEventType int
+
+// Update lifecycle events.
+const (
+	UpdateAvailable UpdateEventType = iota
+	UpdateApplied
+	UpdateFailed
+)
+
+var updateEventNames = [...]string{
+	"UpdateAvailable",
+	"UpdateApplied",
+	"UpdateFailed",
+}
+
+func (e UpdateEventType) String() string { return updateEventNames[e] }
+
+// UpdateEvent reports a binary-update lifecycle transition observed by
+// CheckForUpdate.
+type UpdateEvent struct {
+	Type   UpdateEventType
+	Result *UpdateResult
+	Err    error
+}
+
+// UpdateEvents return the channel CheckForUpdate publishes UpdateEvent
+// values on. The channel is created on first use and is shared across
+// calls.
+func (c *Client) UpdateEvents() <-chan UpdateEvent {
+	c.updateEventsOnce.Do(func() {
+		c.updateEvents = make(chan UpdateEvent, 4)
+	})
+	return c.updateEvents
+}
+
+// emitUpdate publishes ev on UpdateEvents(), dropping it if nothing is
+// reading fast enough rather than blocking the caller.
+func (c *Client) emitUpdate(ev UpdateEvent) {
+	c.updateEventsOnce.Do(func() {
+		c.updateEvents = make(chan UpdateEvent, 4)
+	})
+	select {
+	case c.updateEvents <- ev:
+	default:
+	}
+}
+
+// updater builds an update.Updater from Options.UpdateEndpoint and
+// Options.UpdatePublicKeyPEM.
+func (c *Client) updater() (*update.Updater, error) {
+	if c.Options == nil || c.Options.UpdateEndpoint == "" {
+		return nil, errors.New("gonnel: Options.UpdateEndpoint not configured")
+	}
+	return update.New(c.Options.UpdateEndpoint, c.Options.UpdatePublicKeyPEM), nil
+}
+
+// CheckForUpdate polls Options.UpdateEndpoint for a newer ngrok binary,
+// downloads it and verifies its signature against
+// Options.UpdatePublicKeyPEM. It returns a nil result when no update is
+// available.
+//
+// Under AutoUpdate, a found update is applied immediately via ApplyUpdate;
+// if that fails because the process lacks write permission on
+// Options.BinaryPath, CheckForUpdate falls back to emitting UpdateAvailable
+// on UpdateEvents() instead of returning an error. Under ManualUpdate (the
+// default), every found update is surfaced the same way and left for the
+// caller to apply.
+func (c *Client) CheckForUpdate() (*UpdateResult, error) {
+	return c.CheckForUpdateContext(context.Background())
+}
+
+// CheckForUpdateContext is CheckForUpdate with a context that can cancel
+// the check and download.
+func (c *Client) CheckForUpdateContext(ctx context.Context) (*UpdateResult, error) {
+	u, err := c.updater()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := u.Check(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	if err := u.Fetch(ctx, result); err != nil {
+		return nil, err
+	}
+
+	if c.Options.UpdateMode != AutoUpdate {
+		c.emitUpdate(UpdateEvent{Type: UpdateAvailable, Result: result})
+		return result, nil
+	}
+
+	if err := c.ApplyUpdate(result); err != nil {
+		if os.IsPermission(err) {
+			c.emitUpdate(UpdateEvent{Type: UpdateAvailable, Result: result, Err: err})
+			return result, nil
+		}
+		c.emitUpdate(UpdateEvent{Type: UpdateFailed, Result: result, Err: err})
+		return nil, err
+	}
+
+	c.emitUpdate(UpdateEvent{Type: UpdateApplied, Result: result})
+	return result, nil
+}
+
+// ApplyUpdate atomically swaps Options.BinaryPath for result's verified
+// binary. It takes effect on the next StartServer/StartServerContext call;
+// it does not affect an already-running binary.
+func (c *Client) ApplyUpdate(result *UpdateResult) error {
+	u, err := c.updater()
+	if err != nil {
+		return err
+	}
+	return u.Apply(result, c.Options.BinaryPath)
+}
@@ -0,0 +1,221 @@
+// Package update implements a signature-verified binary auto-updater: it
+// checks a configurable endpoint for a newer binary, downloads it,
+// verifies an ECDSA or RSA signature against an embedded PEM public key,
+// and atomically swaps it into place.
+package update
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Mode selects who applies an update once one is found.
+type Mode int
+
+// Update initiative modes.
+const (
+	// Manual surfaces a found update and leaves applying it to the caller.
+	Manual Mode = iota
+	// Auto applies a found update immediately if permissions allow.
+	Auto
+)
+
+var modes = [...]string{"MANUAL", "AUTO"}
+
+func (m Mode) String() string { return modes[m] }
+
+// Result describes a candidate binary update. Check returns one; once
+// Fetch has downloaded its binary and verified its signature, it is
+// ready for Apply.
+type Result struct {
+	Version   string // version string reported by the endpoint
+	URL       string // download URL for the binary
+	Signature []byte // signature over the downloaded binary, verified by Fetch
+
+	binary []byte // populated by Fetch
+}
+
+// checkResponse is the payload expected from Updater.Endpoint.
+type checkResponse struct {
+	Available bool   `json:"available"`
+	Version   string `json:"version"`
+	URL       string `json:"url"`
+	Signature string `json:"signature"` // base64-encoded
+}
+
+// Updater checks Endpoint for newer binaries and verifies them against
+// PublicKeyPEM before Apply swaps them into place.
+type Updater struct {
+	Endpoint     string       // e.g. "https://example.com/ngrok/latest"
+	PublicKeyPEM []byte       // PEM-encoded ECDSA or RSA public key
+	Client       *http.Client // HTTP client used for Check/Fetch, defaults to http.DefaultClient
+}
+
+// New returns an Updater for the given endpoint and public key.
+func New(endpoint string, publicKeyPEM []byte) *Updater {
+	return &Updater{Endpoint: endpoint, PublicKeyPEM: publicKeyPEM, Client: http.DefaultClient}
+}
+
+func (u *Updater) client() *http.Client {
+	if u.Client != nil {
+		return u.Client
+	}
+	return http.DefaultClient
+}
+
+// Check asks Endpoint whether a binary newer than currentVersion is
+// available. It returns a nil Result when there is nothing to update to.
+func (u *Updater) Check(ctx context.Context, currentVersion string) (*Result, error) {
+	q := url.Values{"current_version": {currentVersion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := u.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return nil, fmt.Errorf("update: error checking %s: %s", u.Endpoint, string(body))
+	}
+
+	var cr checkResponse
+	if err := json.NewDecoder(res.Body).Decode(&cr); err != nil {
+		return nil, err
+	}
+	if !cr.Available {
+		return nil, nil
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(cr.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("update: invalid signature encoding: %w", err)
+	}
+
+	return &Result{Version: cr.Version, URL: cr.URL, Signature: sig}, nil
+}
+
+// Fetch downloads r's binary and verifies its signature against
+// PublicKeyPEM. It must succeed before Apply will accept r.
+func (u *Updater) Fetch(ctx context.Context, r *Result) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := u.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("update: error downloading %s: %s", r.URL, string(body))
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	r.binary = body
+
+	if err := u.verify(r); err != nil {
+		r.binary = nil
+		return err
+	}
+	return nil
+}
+
+// verify checks r.Signature against r.binary using PublicKeyPEM.
+func (u *Updater) verify(r *Result) error {
+	block, _ := pem.Decode(u.PublicKeyPEM)
+	if block == nil {
+		return errors.New("update: invalid public key PEM")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("update: invalid public key: %w", err)
+	}
+
+	sum := sha256.Sum256(r.binary)
+
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, sum[:], r.Signature) {
+			return errors.New("update: signature verification failed")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], r.Signature); err != nil {
+			return fmt.Errorf("update: signature verification failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("update: unsupported public key type %T", pub)
+	}
+
+	return nil
+}
+
+// Apply atomically replaces binaryPath with r's verified binary,
+// preserving binaryPath's existing file mode. It returns an
+// os.IsPermission error when the process lacks write permission on
+// binaryPath's directory, so callers can fall back to a manual
+// notification instead of retrying.
+func (u *Updater) Apply(r *Result, binaryPath string) error {
+	if len(r.binary) == 0 {
+		return errors.New("update: result has no binary, call Fetch first")
+	}
+
+	mode := os.FileMode(0o755)
+	if info, err := os.Stat(binaryPath); err == nil {
+		mode = info.Mode()
+	}
+
+	dir := filepath.Dir(binaryPath)
+	tmp, err := ioutil.TempFile(dir, ".gonnel-update-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(r.binary); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, binaryPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
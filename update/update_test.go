@@ -0,0 +1,104 @@
+package update
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func ecdsaPublicKeyPEM(t *testing.T, priv *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal ecdsa public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func rsaPublicKeyPEM(t *testing.T, priv *rsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal rsa public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestVerifyECDSA(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	binary := []byte("gonnel binary contents")
+	sum := sha256.Sum256(binary)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, sum[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	u := &Updater{PublicKeyPEM: ecdsaPublicKeyPEM(t, priv)}
+
+	if err := u.verify(&Result{Signature: sig, binary: binary}); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+
+	if err := u.verify(&Result{Signature: sig, binary: []byte("tampered")}); err == nil {
+		t.Error("tampered binary accepted")
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate other key: %v", err)
+	}
+	wrongKeyUpdater := &Updater{PublicKeyPEM: ecdsaPublicKeyPEM(t, other)}
+	if err := wrongKeyUpdater.verify(&Result{Signature: sig, binary: binary}); err == nil {
+		t.Error("signature accepted under wrong public key")
+	}
+}
+
+func TestVerifyRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	binary := []byte("gonnel binary contents")
+	sum := sha256.Sum256(binary)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	u := &Updater{PublicKeyPEM: rsaPublicKeyPEM(t, priv)}
+
+	if err := u.verify(&Result{Signature: sig, binary: binary}); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+
+	if err := u.verify(&Result{Signature: sig, binary: []byte("tampered")}); err == nil {
+		t.Error("tampered binary accepted")
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate other key: %v", err)
+	}
+	wrongKeyUpdater := &Updater{PublicKeyPEM: rsaPublicKeyPEM(t, other)}
+	if err := wrongKeyUpdater.verify(&Result{Signature: sig, binary: binary}); err == nil {
+		t.Error("signature accepted under wrong public key")
+	}
+}
+
+func TestVerifyInvalidPublicKeyPEM(t *testing.T) {
+	u := &Updater{PublicKeyPEM: []byte("not a pem block")}
+	if err := u.verify(&Result{Signature: []byte("sig"), binary: []byte("bin")}); err == nil {
+		t.Error("invalid PEM accepted")
+	}
+}
@@ -0,0 +1,51 @@
+// Package util holds small helpers shared across gonnel's packages.
+package util
+
+import "sync"
+
+// Broadcast fans a single stream of messages out to any number of
+// subscribers, e.g. the browser tabs watching Client.ServeDashboard. A slow
+// subscriber has messages dropped rather than blocking the publisher.
+type Broadcast struct {
+	mu   sync.Mutex
+	subs map[chan []byte]struct{}
+}
+
+// NewBroadcast returns an empty Broadcast ready to use.
+func NewBroadcast() *Broadcast {
+	return &Broadcast{subs: make(map[chan []byte]struct{})}
+}
+
+// Subscribe registers a new subscriber and returns its channel. Callers
+// must Unsubscribe when done to release it.
+func (b *Broadcast) Subscribe() chan []byte {
+	ch := make(chan []byte, 16)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes and closes a subscriber channel previously returned
+// by Subscribe.
+func (b *Broadcast) Unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+	b.mu.Unlock()
+}
+
+// Publish sends msg to every current subscriber. Subscribers whose buffer
+// is full miss the message instead of blocking Publish.
+func (b *Broadcast) Publish(msg []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package gonnel
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatInterval is how often Watch pings each tunnel when
+// Client.HeartbeatInterval is unset.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// DefaultMaxPongLatency is how long Watch waits since the last successful
+// pong before marking a tunnel unhealthy when Client.MaxPongLatency is
+// unset.
+const DefaultMaxPongLatency = 90 * time.Second
+
+// TunnelEventType identifies the kind of TunnelEvent emitted by Watch.
+type TunnelEventType int
+
+// Tunnel lifecycle events emitted on Client.Events().
+const (
+	Connected TunnelEventType = iota
+	Disconnected
+	Reconnecting
+	Healthy
+)
+
+var tunnelEventNames = [...]string{
+	"Connected",
+	"Disconnected",
+	"Reconnecting",
+	"Healthy",
+}
+
+func (e TunnelEventType) String() string { return tunnelEventNames[e] }
+
+// TunnelEvent reports a lifecycle transition observed by Watch for a
+// single tunnel.
+type TunnelEvent struct {
+	Type   TunnelEventType
+	Tunnel *Tunnel
+	Err    error
+}
+
+// Events return the channel Watch publishes TunnelEvent values on. The
+// channel is created on first use and is shared across calls.
+func (c *Client) Events() <-chan TunnelEvent {
+	c.eventsOnce.Do(func() {
+		c.events = make(chan TunnelEvent, 16)
+	})
+	return c.events
+}
+
+// emit publishes ev on Events(), dropping it if nothing is reading fast
+// enough rather than blocking the heartbeat loop.
+func (c *Client) emit(ev TunnelEvent) {
+	c.eventsOnce.Do(func() {
+		c.events = make(chan TunnelEvent, 16)
+	})
+	select {
+	case c.events <- ev:
+	default:
+	}
+}
+
+// Watch pings every tunnel's RemoteAddress every HeartbeatInterval (HEAD
+// request for HTTP/TLS tunnels, TCP dial for TCP tunnels) and reconnects
+// any tunnel that hasn't ponged within MaxPongLatency. It runs until ctx is
+// done and is meant to be started in its own goroutine for long-lived
+// services.
+func (c *Client) Watch(ctx context.Context) {
+	interval := c.HeartbeatInterval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.heartbeatAll(ctx)
+		}
+	}
+}
+
+// heartbeatAll pings every tunnel concurrently.
+func (c *Client) heartbeatAll(ctx context.Context) {
+	wg := &sync.WaitGroup{}
+	for _, t := range c.Tunnel {
+		if !t.IsCreated {
+			continue
+		}
+		wg.Add(1)
+		go func(t *Tunnel) {
+			defer wg.Done()
+			c.heartbeat(ctx, t)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// heartbeat pings a single tunnel. Once it has been unreachable for longer
+// than MaxPongLatency it is marked unhealthy and reconnected; the reconnect
+// is retried on every subsequent tick, backing off between attempts, until
+// it succeeds or the tunnel is removed.
+func (c *Client) heartbeat(ctx context.Context, t *Tunnel) {
+	maxLatency := c.MaxPongLatency
+	if maxLatency <= 0 {
+		maxLatency = DefaultMaxPongLatency
+	}
+
+	if err := pingTunnel(ctx, t); err != nil {
+		t.ConsecutiveFailures++
+
+		if t.Healthy {
+			if t.LastPong.IsZero() || time.Since(t.LastPong) <= maxLatency {
+				return
+			}
+			t.Healthy = false
+			t.reconnectBackoff = c.backoff()
+			c.emit(TunnelEvent{Type: Disconnected, Tunnel: t, Err: err})
+		}
+
+		// Already unhealthy (or just became so) - keep retrying the
+		// reconnect, backing off between attempts, until it succeeds.
+		if t.reconnectBackoff == nil {
+			t.reconnectBackoff = c.backoff()
+		}
+		if wait := t.reconnectBackoff.NextBackOff(); wait != Stop {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		c.emit(TunnelEvent{Type: Reconnecting, Tunnel: t, Err: err})
+		if err := c.createTunnelOnce(ctx, t); err != nil {
+			return
+		}
+		t.Healthy = true
+		t.LastPong = time.Now()
+		t.ConsecutiveFailures = 0
+		t.reconnectBackoff = nil
+		c.emit(TunnelEvent{Type: Connected, Tunnel: t})
+		return
+	}
+
+	wasHealthy := t.Healthy
+	t.Healthy = true
+	t.LastPong = time.Now()
+	t.ConsecutiveFailures = 0
+	t.reconnectBackoff = nil
+	if !wasHealthy {
+		c.emit(TunnelEvent{Type: Healthy, Tunnel: t})
+	}
+}
+
+// pingTunnel checks that t.RemoteAddress is reachable, using a HEAD
+// request for HTTP/TLS tunnels and a TCP dial for TCP tunnels.
+func pingTunnel(ctx context.Context, t *Tunnel) error {
+	if t.Proto == TCP {
+		d := net.Dialer{}
+		conn, err := d.DialContext(ctx, "tcp", t.RemoteAddress)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, t.RemoteAddress, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return nil
+}